@@ -0,0 +1,185 @@
+package epsagon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TracerStats is a snapshot of a tracer's internal state, returned by
+// Stats() and served at the /epsagon/stats admin endpoint.
+type TracerStats struct {
+	BufferedEvents     int       `json:"buffered_events"`
+	BufferedExceptions int       `json:"buffered_exceptions"`
+	DroppedEvents      int64     `json:"dropped_events"`
+	DroppedExceptions  int64     `json:"dropped_exceptions"`
+	LastSendTime       time.Time `json:"last_send_time"`
+	LastSendError      string    `json:"last_send_error,omitempty"`
+	RetryCount         int64     `json:"retry_count"`
+}
+
+// LevelSetter is implemented by Loggers that also want to be told about
+// runtime level changes, such as the default stdLogger. It's optional: the
+// /epsagon/level admin endpoint gates what it logs through the tracer's own
+// level regardless of whether Logger implements it, so every Logger
+// responds to level changes, but a LevelSetter additionally hears about them.
+type LevelSetter interface {
+	SetLevel(LogLevel)
+}
+
+// SetLevel implements LevelSetter for stdLogger.
+func (logger *stdLogger) SetLevel(level LogLevel) {
+	logger.Level = level
+}
+
+// Flush forces the tracer to send its currently buffered events and
+// exceptions immediately, without stopping it.
+func (tracer *epsagonTracer) Flush() {
+	ack := make(chan struct{})
+	select {
+	case <-tracer.stopped:
+		return
+	case tracer.flushCmd <- ack:
+	}
+	select {
+	case <-tracer.stopped:
+	case <-ack:
+	}
+}
+
+// Stats returns a snapshot of the tracer's buffered event/exception counts
+// and the outcome of its last send attempt.
+func (tracer *epsagonTracer) Stats() TracerStats {
+	tracer.statsMu.Lock()
+	defer tracer.statsMu.Unlock()
+	return tracer.stats
+}
+
+// updateBufferStats refreshes the buffered event/exception counts in
+// tracer.stats. It must only be called from the Run goroutine, which is the
+// sole owner of tracer.events/tracer.exceptions; Stats() itself never reads
+// those slices, since they're unsynchronized outside that goroutine.
+func (tracer *epsagonTracer) updateBufferStats() {
+	tracer.statsMu.Lock()
+	defer tracer.statsMu.Unlock()
+	tracer.stats.BufferedEvents = len(tracer.events)
+	tracer.stats.BufferedExceptions = len(tracer.exceptions)
+}
+
+// recordDrop counts a buffered event or exception dropped by appendEvent/
+// appendException because the buffer hit its cap.
+func (tracer *epsagonTracer) recordDrop(isEvent bool) {
+	tracer.statsMu.Lock()
+	defer tracer.statsMu.Unlock()
+	if isEvent {
+		tracer.stats.DroppedEvents++
+	} else {
+		tracer.stats.DroppedExceptions++
+	}
+}
+
+func (tracer *epsagonTracer) recordSendResult(sendTime time.Time, err error) {
+	tracer.statsMu.Lock()
+	defer tracer.statsMu.Unlock()
+	tracer.stats.LastSendTime = sendTime
+	if err != nil {
+		tracer.stats.LastSendError = err.Error()
+	} else {
+		tracer.stats.LastSendError = ""
+	}
+	if counter, ok := tracer.exporter.(interface{ Retries() int64 }); ok {
+		tracer.stats.RetryCount = counter.Retries()
+	}
+}
+
+// redactedConfig is the JSON shape served at /epsagon/config: the config's
+// plain settings without the token, which could be used to exfiltrate
+// traces to an attacker's own Epsagon account. AdminListenAddr and the
+// Exporter, HTTPClient, Sampler and Logger hooks are not included either.
+type redactedConfig struct {
+	ApplicationName  string        `json:"application_name"`
+	CollectorURL     string        `json:"collector_url"`
+	MetadataOnly     bool          `json:"metadata_only"`
+	Debug            bool          `json:"debug"`
+	BatchSize        int           `json:"batch_size"`
+	FlushInterval    time.Duration `json:"flush_interval"`
+	MaxRetries       int           `json:"max_retries"`
+	Compression      bool          `json:"compression"`
+	SampleRate       float64       `json:"sample_rate"`
+	FilterEventTypes []string      `json:"filter_event_types"`
+	LogLevel         LogLevel      `json:"log_level"`
+}
+
+func (tracer *epsagonTracer) redactedConfig() redactedConfig {
+	config := tracer.Config
+	return redactedConfig{
+		ApplicationName:  config.ApplicationName,
+		CollectorURL:     config.CollectorURL,
+		MetadataOnly:     config.MetadataOnly,
+		Debug:            config.Debug,
+		BatchSize:        config.BatchSize,
+		FlushInterval:    config.FlushInterval,
+		MaxRetries:       config.MaxRetries,
+		Compression:      config.Compression,
+		SampleRate:       config.SampleRate,
+		FilterEventTypes: config.FilterEventTypes,
+		LogLevel:         tracer.logLevel(),
+	}
+}
+
+// adminServer serves the opt-in HTTP admin endpoints rooted at /epsagon/:
+// flush, stats, config and level. It's started by CreateTracer when
+// Config.AdminListenAddr is set, and stopped alongside the tracer.
+type adminServer struct {
+	http     *http.Server
+	shutdown sync.Once
+}
+
+func startAdminServer(tracer *epsagonTracer) *adminServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/epsagon/flush", func(w http.ResponseWriter, r *http.Request) {
+		tracer.Flush()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/epsagon/stats", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tracer.Stats())
+	})
+	mux.HandleFunc("/epsagon/config", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tracer.redactedConfig())
+	})
+	mux.HandleFunc("/epsagon/level", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(map[string]LogLevel{"level": tracer.logLevel()})
+			return
+		}
+		var body struct{ Level LogLevel }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		atomic.StoreInt32(&tracer.level, int32(body.Level))
+		if setter, ok := tracer.Config.Logger.(LevelSetter); ok {
+			setter.SetLevel(body.Level)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := &adminServer{http: &http.Server{Addr: tracer.Config.AdminListenAddr, Handler: mux}}
+	go func() {
+		if err := server.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			tracer.errorf("admin server stopped: %v", err)
+		}
+	}()
+	return server
+}
+
+func (server *adminServer) Stop() {
+	server.shutdown.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.http.Shutdown(ctx)
+	})
+}
@@ -0,0 +1,157 @@
+package epsagon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	protocol "github.com/epsagon/epsagon-go/protocol"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+// TraceExporter sends a collected trace to its destination. Implementations
+// are free to batch, retry, or compress as they see fit; HTTPExporter is the
+// default used by CreateTracer.
+type TraceExporter interface {
+	Export(trace *protocol.Trace) error
+}
+
+// HTTPExporter is the default TraceExporter. It posts the JSONPB-encoded
+// trace to a collector URL over HTTP(S), optionally gzip-compressing the
+// body and retrying on 5xx responses or network errors with an exponential
+// backoff and jitter.
+type HTTPExporter struct {
+	CollectorURL string
+	Client       *http.Client
+	Compression  bool
+	MaxRetries   int
+
+	retries int64
+}
+
+// Retries returns the cumulative number of retried (i.e. non-first) send
+// attempts made by this exporter, for TracerStats.
+func (exporter *HTTPExporter) Retries() int64 {
+	return atomic.LoadInt64(&exporter.retries)
+}
+
+func marshalTrace(trace *protocol.Trace) ([]byte, error) {
+	marshaler := jsonpb.Marshaler{
+		EnumsAsInts: true, EmitDefaults: true, OrigName: true}
+	traceJSON, err := marshaler.MarshalToString(trace)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(traceJSON), nil
+}
+
+func gzipCompress(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Export implements TraceExporter.
+func (exporter *HTTPExporter) Export(trace *protocol.Trace) error {
+	payload, err := marshalTrace(trace)
+	if err != nil {
+		return fmt.Errorf("failed marshaling the traces: %v", err)
+	}
+
+	contentEncoding := ""
+	if exporter.Compression {
+		payload, err = gzipCompress(payload)
+		if err != nil {
+			return fmt.Errorf("failed gzip-compressing the traces: %v", err)
+		}
+		contentEncoding = "gzip"
+	}
+
+	client := exporter.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Second}
+	}
+
+	maxRetries := exporter.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			atomic.AddInt64(&exporter.retries, 1)
+			time.Sleep(backoffWithJitter(attempt))
+		}
+		lastErr = exporter.post(client, payload, contentEncoding)
+		if lastErr == nil {
+			return nil
+		}
+		if statusErr, ok := lastErr.(*collectorStatusError); ok && !statusErr.retryable {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// collectorStatusError is returned by post for any non-2xx collector
+// response. retryable is true for 5xx responses, which may succeed on a
+// later attempt; 4xx responses (bad token, bad request, oversized payload,
+// ...) won't change by retrying the same payload, but are still reported as
+// errors rather than treated as a successful send.
+type collectorStatusError struct {
+	statusCode int
+	body       string
+	retryable  bool
+}
+
+func (err *collectorStatusError) Error() string {
+	return fmt.Sprintf("collector returned status %d: %s", err.statusCode, err.body)
+}
+
+func (exporter *HTTPExporter) post(client *http.Client, payload []byte, contentEncoding string) error {
+	req, err := http.NewRequest(http.MethodPost, exporter.CollectorURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	return &collectorStatusError{
+		statusCode: resp.StatusCode,
+		body:       string(body),
+		retryable:  resp.StatusCode >= 500,
+	}
+}
+
+// backoffWithJitter returns an exponential backoff duration for the given
+// retry attempt (1-indexed), with up to 50% random jitter added to avoid
+// thundering-herd retries.
+func backoffWithJitter(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	return base + jitter/2
+}
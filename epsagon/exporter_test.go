@@ -0,0 +1,52 @@
+package epsagon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestGzipCompressRoundTrip(t *testing.T) {
+	payload := []byte(`{"some":"json payload"}`)
+	compressed, err := gzipCompress(payload)
+	if err != nil {
+		t.Fatalf("gzipCompress returned an error: %v", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to read compressed payload as gzip: %v", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decompress payload: %v", err)
+	}
+	if !bytes.Equal(decompressed, payload) {
+		t.Fatalf("round trip mismatch: got %q, want %q", decompressed, payload)
+	}
+}
+
+func TestBackoffWithJitterGrowsAndStaysBounded(t *testing.T) {
+	previous := time.Duration(0)
+	for attempt := 1; attempt <= 5; attempt++ {
+		base := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+		maxWithJitter := base + base/2
+		for i := 0; i < 20; i++ {
+			backoff := backoffWithJitter(attempt)
+			if backoff < base {
+				t.Fatalf("attempt %d: backoff %v is below its base %v", attempt, backoff, base)
+			}
+			if backoff > maxWithJitter {
+				t.Fatalf("attempt %d: backoff %v exceeds base+50%% jitter %v", attempt, backoff, maxWithJitter)
+			}
+		}
+		if base <= previous && attempt > 1 {
+			t.Fatalf("attempt %d: base backoff %v did not grow from previous %v", attempt, base, previous)
+		}
+		previous = base
+	}
+}
@@ -0,0 +1,72 @@
+package epsagon
+
+import (
+	"log"
+)
+
+// LogLevel controls which Logger methods a tracer emits.
+type LogLevel int
+
+// Log levels, in increasing severity. Debugf is gated on LevelDebug, Infof
+// on LevelInfo or below, and so on; Errorf is always emitted. The zero
+// value is intentionally unused so that a zero-value Config.LogLevel can be
+// distinguished from an explicit LevelDebug (see Config.LogLevel).
+const (
+	_ LogLevel = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Logger is the logging interface used throughout the tracer, so
+// applications that embed Epsagon can route its diagnostics into their own
+// logging pipeline instead of the standard log package. Adapters for
+// logrus and zap are provided in the epsagon/logrusadapter and
+// epsagon/zapadapter subpackages.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// stdLogger adapts the standard library log package to Logger, gating
+// Debugf/Infof/Warnf on Level. It's the default Logger used when
+// Config.Logger is unset.
+type stdLogger struct {
+	Level LogLevel
+}
+
+func (logger *stdLogger) Debugf(format string, args ...interface{}) {
+	if logger.Level <= LevelDebug {
+		log.Printf("EPSAGON DEBUG: "+format, args...)
+	}
+}
+
+func (logger *stdLogger) Infof(format string, args ...interface{}) {
+	if logger.Level <= LevelInfo {
+		log.Printf(format, args...)
+	}
+}
+
+func (logger *stdLogger) Warnf(format string, args ...interface{}) {
+	if logger.Level <= LevelWarn {
+		log.Printf("WARN: "+format, args...)
+	}
+}
+
+func (logger *stdLogger) Errorf(format string, args ...interface{}) {
+	log.Printf("ERROR: "+format, args...)
+}
+
+// defaultLogger is used by package-level functions (AddEvent, StopTracer,
+// ...) that may run before a tracer - and therefore a Config.Logger - exists.
+var defaultLogger Logger = &stdLogger{Level: LevelInfo}
+
+// WithLogger sets config's Logger and returns config, so it can be chained
+// off of a Config literal, e.g. (&Config{Token: t}).WithLogger(myLogger).
+func (config *Config) WithLogger(logger Logger) *Config {
+	config.Logger = logger
+	return config
+}
@@ -0,0 +1,39 @@
+// Package logrusadapter adapts a logrus.FieldLogger to epsagon.Logger, so a
+// service that already logs with logrus can route the tracer's
+// diagnostics through it instead of the standard log package.
+package logrusadapter
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Adapter wraps a logrus.FieldLogger as an epsagon.Logger.
+type Adapter struct {
+	Logger logrus.FieldLogger
+}
+
+// New returns an Adapter around logger, ready to be set as
+// epsagon.Config.Logger (or via Config.WithLogger).
+func New(logger logrus.FieldLogger) *Adapter {
+	return &Adapter{Logger: logger}
+}
+
+// Debugf implements epsagon.Logger.
+func (adapter *Adapter) Debugf(format string, args ...interface{}) {
+	adapter.Logger.Debugf(format, args...)
+}
+
+// Infof implements epsagon.Logger.
+func (adapter *Adapter) Infof(format string, args ...interface{}) {
+	adapter.Logger.Infof(format, args...)
+}
+
+// Warnf implements epsagon.Logger.
+func (adapter *Adapter) Warnf(format string, args ...interface{}) {
+	adapter.Logger.Warnf(format, args...)
+}
+
+// Errorf implements epsagon.Logger.
+func (adapter *Adapter) Errorf(format string, args ...interface{}) {
+	adapter.Logger.Errorf(format, args...)
+}
@@ -0,0 +1,107 @@
+package epsagon
+
+import (
+	"hash/fnv"
+	"math"
+
+	protocol "github.com/epsagon/epsagon-go/protocol"
+)
+
+// Sampler decides whether a given event should be kept or dropped before it
+// is enqueued on the tracer. Implementations should be deterministic per
+// trace id (see traceID below) so that every event belonging to the same
+// distributed trace is either kept or dropped as a unit, even when events
+// arrive from different Epsagon- or OTel-instrumented services.
+type Sampler interface {
+	ShouldSample(event *protocol.Event) bool
+}
+
+// rateSampler is the Sampler installed by fillConfigDefaults when
+// Config.SampleRate is set and Config.Sampler is not.
+type rateSampler struct {
+	rate float64
+	// runID is the fallback trace id for events with no OTel-bridge
+	// trace_id, i.e. every event produced directly by this tracer instance
+	// (see CreateTracer). Using one id for the whole tracer instance,
+	// rather than each event's own id, is what makes a native invocation's
+	// events sample as a single unit instead of independently. This fits
+	// the one-CreateTracer-per-invocation pattern (e.g. Lambda); a
+	// long-running process that calls CreateTracer once and keeps it
+	// running will get the same keep/drop decision for every native event
+	// for the process's whole lifetime, since there's no per-request
+	// boundary here to derive a fresh id from. Such services should supply
+	// their own Config.Sampler that derives an id from request context.
+	runID string
+}
+
+// ShouldSample keeps a deterministic fraction of traces, sized by rate.
+func (sampler *rateSampler) ShouldSample(event *protocol.Event) bool {
+	if sampler.rate >= 1 {
+		return true
+	}
+	if sampler.rate <= 0 {
+		return false
+	}
+	return traceIDFraction(event, sampler.runID) < sampler.rate
+}
+
+// traceID returns the identifier shared by every event in a distributed
+// trace. Events produced by the OTel bridge carry it in Resource.Metadata
+// ("trace_id", set by tracing.EventFromSpan); other events fall back to
+// fallbackID, which callers should derive from something shared by every
+// event in the same trace rather than any single event's own id.
+func traceID(event *protocol.Event, fallbackID string) string {
+	if event.Resource != nil {
+		if id, ok := event.Resource.Metadata["trace_id"]; ok && id != "" {
+			return id
+		}
+	}
+	return fallbackID
+}
+
+// traceIDFraction maps a trace id onto [0, 1) deterministically, so the
+// same trace id always yields the same fraction regardless of which
+// process or language evaluates it.
+func traceIDFraction(event *protocol.Event, fallbackID string) float64 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(traceID(event, fallbackID)))
+	return float64(hasher.Sum32()) / (float64(math.MaxUint32) + 1)
+}
+
+// metadataOnlyKeys lists the Resource.Metadata keys, per resource type, that
+// hold full request/response payload bodies. They are stripped by
+// applyMetadataOnly when Config.MetadataOnly is set. Each list includes both
+// the native key and the OTel semantic-convention attribute name tracing.
+// EventFromSpan copies verbatim into metadata for bridged spans mapped to
+// the same resource type (see tracing.otelResourceType).
+var metadataOnlyKeys = map[string][]string{
+	"http":     {"request_body", "response_body", "http.request.body", "http.response.body"},
+	"database": {"query", "query_result", "db.statement"},
+	"queue":    {"message_body", "messaging.message.payload"},
+}
+
+// applyMetadataOnly strips payload bodies from an event's resource metadata
+// in place, leaving only identifying metadata (e.g. URL, table name, queue
+// name) behind.
+func applyMetadataOnly(event *protocol.Event) {
+	if event.Resource == nil || event.Resource.Metadata == nil {
+		return
+	}
+	for _, key := range metadataOnlyKeys[event.Resource.Type] {
+		delete(event.Resource.Metadata, key)
+	}
+}
+
+// filtersEventType reports whether config.FilterEventTypes names the given
+// event's resource type, meaning it should be dropped entirely.
+func filtersEventType(config *Config, event *protocol.Event) bool {
+	if len(config.FilterEventTypes) == 0 || event.Resource == nil {
+		return false
+	}
+	for _, eventType := range config.FilterEventTypes {
+		if eventType == event.Resource.Type {
+			return true
+		}
+	}
+	return false
+}
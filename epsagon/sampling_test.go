@@ -0,0 +1,101 @@
+package epsagon
+
+import (
+	"testing"
+
+	protocol "github.com/epsagon/epsagon-go/protocol"
+)
+
+func eventWithTraceID(id string) *protocol.Event {
+	return &protocol.Event{
+		Id:       "event-" + id,
+		Resource: &protocol.Resource{Metadata: map[string]string{"trace_id": id}},
+	}
+}
+
+func TestTraceIDFractionDeterministic(t *testing.T) {
+	event := eventWithTraceID("abc-123")
+	first := traceIDFraction(event, "fallback")
+	second := traceIDFraction(event, "fallback")
+	if first != second {
+		t.Fatalf("traceIDFraction is not deterministic: got %v then %v", first, second)
+	}
+	if first < 0 || first >= 1 {
+		t.Fatalf("traceIDFraction out of [0, 1): got %v", first)
+	}
+}
+
+func TestTraceIDFractionUsesFallbackWhenNoTraceID(t *testing.T) {
+	event := &protocol.Event{Id: "some-event-id"}
+	withFallback := traceIDFraction(event, "fallback-a")
+	otherFallback := traceIDFraction(event, "fallback-b")
+	if withFallback == otherFallback {
+		t.Fatalf("expected different fallbacks to produce different fractions")
+	}
+}
+
+func TestRateSamplerSamplesByTraceIDNotEventID(t *testing.T) {
+	sampler := &rateSampler{rate: 0.5, runID: "run-1"}
+	first := eventWithTraceID("trace-1")
+	second := &protocol.Event{
+		Id:       "a-completely-different-event-id",
+		Resource: &protocol.Resource{Metadata: map[string]string{"trace_id": "trace-1"}},
+	}
+	if sampler.ShouldSample(first) != sampler.ShouldSample(second) {
+		t.Fatalf("events sharing a trace id must be sampled as a unit")
+	}
+}
+
+func TestRateSamplerBoundaries(t *testing.T) {
+	event := eventWithTraceID("trace-1")
+	if !(&rateSampler{rate: 1}).ShouldSample(event) {
+		t.Fatalf("rate 1 must always sample")
+	}
+	if (&rateSampler{rate: 0}).ShouldSample(event) {
+		t.Fatalf("rate 0 must never sample")
+	}
+}
+
+func TestApplyMetadataOnlyStripsPayloadKeys(t *testing.T) {
+	event := &protocol.Event{
+		Resource: &protocol.Resource{
+			Type: "http",
+			Metadata: map[string]string{
+				"request_body":  "secret",
+				"response_body": "secret",
+				"url":           "https://example.com",
+			},
+		},
+	}
+	applyMetadataOnly(event)
+	if _, ok := event.Resource.Metadata["request_body"]; ok {
+		t.Errorf("request_body should have been stripped")
+	}
+	if _, ok := event.Resource.Metadata["response_body"]; ok {
+		t.Errorf("response_body should have been stripped")
+	}
+	if event.Resource.Metadata["url"] != "https://example.com" {
+		t.Errorf("non-payload metadata should be left alone")
+	}
+}
+
+func TestApplyMetadataOnlyHandlesNilResource(t *testing.T) {
+	event := &protocol.Event{}
+	applyMetadataOnly(event) // must not panic
+}
+
+func TestFiltersEventType(t *testing.T) {
+	config := &Config{FilterEventTypes: []string{"http", "database"}}
+	httpEvent := &protocol.Event{Resource: &protocol.Resource{Type: "http"}}
+	queueEvent := &protocol.Event{Resource: &protocol.Resource{Type: "queue"}}
+
+	if !filtersEventType(config, httpEvent) {
+		t.Errorf("expected http events to be filtered")
+	}
+	if filtersEventType(config, queueEvent) {
+		t.Errorf("expected queue events to pass through")
+	}
+	if filtersEventType(&Config{}, httpEvent) {
+		t.Errorf("expected no filtering when FilterEventTypes is empty")
+	}
+}
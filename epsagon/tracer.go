@@ -1,16 +1,16 @@
 package epsagon
 
 import (
-	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	protocol "github.com/epsagon/epsagon-go/protocol"
-	"github.com/golang/protobuf/jsonpb"
-	"io"
-	"log"
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,6 +26,8 @@ type tracer interface {
 	Running() bool
 	Stop()
 	Stopped() bool
+	Flush()
+	Stats() TracerStats
 }
 
 // Config is the configuration for Epsagon's tracer
@@ -35,10 +37,59 @@ type Config struct {
 	CollectorURL    string
 	MetadataOnly    bool
 	Debug           bool
+
+	// Exporter overrides how collected traces are sent. Defaults to an
+	// HTTPExporter built from CollectorURL, HTTPClient, Compression and
+	// MaxRetries.
+	Exporter TraceExporter
+	// BatchSize is the number of buffered events+exceptions that triggers
+	// an early flush, without waiting for FlushInterval.
+	BatchSize int
+	// FlushInterval is how often the tracer flushes its buffer to the
+	// collector while running.
+	FlushInterval time.Duration
+	// MaxRetries is the number of attempts the default HTTPExporter makes
+	// per flush before giving up.
+	MaxRetries int
+	// Compression gzip-compresses the JSONPB payload sent by the default
+	// HTTPExporter.
+	Compression bool
+	// HTTPClient is the http.Client used by the default HTTPExporter. A
+	// client with a 1 second timeout is used if unset.
+	HTTPClient *http.Client
+
+	// SampleRate keeps this fraction (0.0-1.0) of traces, chosen
+	// deterministically per trace id. Ignored if Sampler is set. The zero
+	// value (including an unset field) keeps all traces, the same as 1.0 —
+	// there is no way to express "drop everything" via SampleRate, since 0
+	// is indistinguishable from unset; supply a custom Sampler that always
+	// returns false instead.
+	SampleRate float64
+	// Sampler overrides SampleRate with custom sampling logic.
+	Sampler Sampler
+	// FilterEventTypes drops events whose Resource.Type matches one of
+	// these values (e.g. "http", "database") before they reach the
+	// tracer at all.
+	FilterEventTypes []string
+
+	// Logger receives the tracer's diagnostics, instead of the standard
+	// log package. Defaults to a Logger wrapping the standard log
+	// package. See the epsagon/logrusadapter and epsagon/zapadapter
+	// subpackages for ready-made adapters.
+	Logger Logger
+	// LogLevel is the minimum severity Logger emits. If unset, Debug
+	// chooses between LevelDebug and LevelInfo.
+	LogLevel LogLevel
+
+	// AdminListenAddr, if set, starts an HTTP admin server on this
+	// address exposing /epsagon/flush, /epsagon/stats, /epsagon/config
+	// and /epsagon/level for runtime inspection and control.
+	AdminListenAddr string
 }
 
 type epsagonTracer struct {
-	Config *Config
+	Config   *Config
+	exporter TraceExporter
 
 	eventsPipe     chan *protocol.Event
 	events         []*protocol.Event
@@ -46,48 +97,181 @@ type epsagonTracer struct {
 	exceptions     []*protocol.Exception
 
 	closeCmd chan struct{}
+	flushCmd chan chan struct{}
 	stopped  chan struct{}
 	running  chan struct{}
+
+	// exporting is true while an export goroutine started by triggerSend
+	// is in flight; it's owned by the Run goroutine, which is the only
+	// reader/writer. exportingEvents/exportingExceptions are the lengths
+	// of the prefix of events/exceptions that snapshot covers, and must
+	// stay untouched (not evicted by appendEvent/appendException's cap)
+	// until handleExportOutcome trims or retries them.
+	exporting           bool
+	exportingEvents     int
+	exportingExceptions int
+	exportDone          chan exportOutcome
+
+	// level is the tracer's current minimum log severity, read and written
+	// atomically so the /epsagon/level admin endpoint can change it from an
+	// HTTP goroutine while Run/AddEvent log from the tracer goroutine, and
+	// so the change applies uniformly no matter what Logger is configured.
+	level int32
+
+	statsMu sync.Mutex
+	stats   TracerStats
+
+	admin *adminServer
 }
 
-func (tracer *epsagonTracer) sendTraces() {
-	tracesReader, err := tracer.getTraceReader()
-	if err != nil {
-		// TODO create an exception and send a trace only with that
-		log.Printf("Epsagon: Encountered an error while marshaling the traces: %v\n", err)
-		return
+// logLevel returns the tracer's current minimum log severity.
+func (tracer *epsagonTracer) logLevel() LogLevel {
+	return LogLevel(atomic.LoadInt32(&tracer.level))
+}
+
+// debugf, infof, warnf and errorf log through tracer.Config.Logger, gated on
+// the tracer's current level rather than anything the Logger implementation
+// tracks itself. This is what lets /epsagon/level change what's emitted
+// regardless of whether Logger implements LevelSetter.
+func (tracer *epsagonTracer) debugf(format string, args ...interface{}) {
+	if tracer.logLevel() <= LevelDebug {
+		tracer.Config.Logger.Debugf(format, args...)
+	}
+}
+
+func (tracer *epsagonTracer) infof(format string, args ...interface{}) {
+	if tracer.logLevel() <= LevelInfo {
+		tracer.Config.Logger.Infof(format, args...)
+	}
+}
+
+func (tracer *epsagonTracer) warnf(format string, args ...interface{}) {
+	if tracer.logLevel() <= LevelWarn {
+		tracer.Config.Logger.Warnf(format, args...)
+	}
+}
+
+func (tracer *epsagonTracer) errorf(format string, args ...interface{}) {
+	tracer.Config.Logger.Errorf(format, args...)
+}
+
+// bufferCapMultiplier bounds how many batches' worth of events/exceptions
+// can pile up while the collector is unreachable, so a sustained outage
+// drops the oldest data instead of growing memory without bound.
+const bufferCapMultiplier = 20
+
+// exportOutcome is reported by the goroutine triggerSend starts back to the
+// Run loop, which owns tracer.events/tracer.exceptions and therefore must
+// be the one to trim them.
+type exportOutcome struct {
+	sendTime       time.Time
+	err            error
+	sentEvents     int
+	sentExceptions int
+}
+
+// appendEvent appends event to the buffer, dropping the oldest droppable
+// buffered event first if doing so would exceed the configured cap. While
+// an export is in flight, the events it's sending (the first
+// exportingEvents of the buffer) are never dropped: handleExportOutcome
+// trims the live buffer by that same count once the export completes, so
+// dropping one of them here would desynchronize the trim from the buffer's
+// actual contents and corrupt either the trim or a retry.
+func (tracer *epsagonTracer) appendEvent(event *protocol.Event) {
+	capacity := tracer.Config.BatchSize*bufferCapMultiplier + tracer.exportingEvents
+	if len(tracer.events) >= capacity {
+		tracer.events = append(tracer.events[:tracer.exportingEvents], tracer.events[tracer.exportingEvents+1:]...)
+		tracer.recordDrop(true)
+		tracer.warnf("buffered event dropped: at capacity (%d)", capacity)
+	}
+	tracer.events = append(tracer.events, event)
+	tracer.updateBufferStats()
+}
+
+// appendException is appendEvent's counterpart for exceptions.
+func (tracer *epsagonTracer) appendException(exception *protocol.Exception) {
+	capacity := tracer.Config.BatchSize*bufferCapMultiplier + tracer.exportingExceptions
+	if len(tracer.exceptions) >= capacity {
+		tracer.exceptions = append(tracer.exceptions[:tracer.exportingExceptions], tracer.exceptions[tracer.exportingExceptions+1:]...)
+		tracer.recordDrop(false)
+		tracer.warnf("buffered exception dropped: at capacity (%d)", capacity)
 	}
-	client := &http.Client{Timeout: time.Duration(time.Second)}
+	tracer.exceptions = append(tracer.exceptions, exception)
+	tracer.updateBufferStats()
+}
 
-	resp, err := client.Post(tracer.Config.CollectorURL, "application/json", tracesReader)
-	if err != nil {
-		var respBody []byte
-		resp.Body.Read(respBody)
-		resp.Body.Close()
-		log.Printf("Error while sending traces \n%v\n%v\n", err, respBody)
+// triggerSend starts an asynchronous export of a snapshot of the currently
+// buffered events/exceptions, unless one is already in flight or there's
+// nothing to send. The live buffers are left untouched until the export
+// completes (see handleExportOutcome), so a failed export is retried on
+// the next trigger instead of losing data, and AddEvent/AddException never
+// block on network I/O.
+func (tracer *epsagonTracer) triggerSend() {
+	if tracer.exporting || tracer.bufferedCount() == 0 {
+		return
 	}
+	tracer.exporting = true
+	tracer.exportingEvents = len(tracer.events)
+	tracer.exportingExceptions = len(tracer.exceptions)
+
+	events := make([]*protocol.Event, len(tracer.events))
+	copy(events, tracer.events)
+	exceptions := make([]*protocol.Exception, len(tracer.exceptions))
+	copy(exceptions, tracer.exceptions)
+
+	go func() {
+		trace := &protocol.Trace{
+			AppName:    tracer.Config.ApplicationName,
+			Token:      tracer.Config.Token,
+			Events:     events,
+			Exceptions: exceptions,
+			Version:    "0.0.1",
+			Platform:   runtime.Version(),
+		}
+		tracer.exportDone <- exportOutcome{
+			sendTime:       time.Now(),
+			err:            tracer.exporter.Export(trace),
+			sentEvents:     len(events),
+			sentExceptions: len(exceptions),
+		}
+	}()
 }
 
-func (tracer *epsagonTracer) getTraceReader() (io.Reader, error) {
-	version := runtime.Version()
-	trace := protocol.Trace{
-		AppName:    tracer.Config.ApplicationName,
-		Token:      tracer.Config.Token,
-		Events:     tracer.events,
-		Exceptions: tracer.exceptions,
-		Version:    "0.0.1",
-		Platform:   version,
+// handleExportOutcome is called from the Run loop once a triggerSend
+// goroutine completes. On success it trims the sent prefix off the live
+// buffers; on failure it leaves them in place so the next triggerSend
+// retries them (bounded by appendEvent/appendException's cap).
+func (tracer *epsagonTracer) handleExportOutcome(outcome exportOutcome) {
+	tracer.exporting = false
+	tracer.exportingEvents = 0
+	tracer.exportingExceptions = 0
+	tracer.recordSendResult(outcome.sendTime, outcome.err)
+	if outcome.err != nil {
+		tracer.errorf("Epsagon: %v", outcome.err)
+		return
 	}
-	marshaler := jsonpb.Marshaler{
-		EnumsAsInts: true, EmitDefaults: true, OrigName: true}
-	traceJSON, err := marshaler.MarshalToString(&trace)
-	if err != nil {
-		return nil, err
+	tracer.events = tracer.events[outcome.sentEvents:]
+	tracer.exceptions = tracer.exceptions[outcome.sentExceptions:]
+	tracer.updateBufferStats()
+}
+
+func (tracer *epsagonTracer) bufferedCount() int {
+	return len(tracer.events) + len(tracer.exceptions)
+}
+
+// sendAndWait waits out any export already in flight, then triggers one
+// final send of whatever remains and waits for that to complete too. It's
+// used by the flushCmd and closeCmd cases so that, unlike the fire-and-
+// forget triggerSend used on the hot path, Flush()/Stop()/StopTracer()
+// keep their "buffered data is delivered before returning" guarantee.
+func (tracer *epsagonTracer) sendAndWait() {
+	if tracer.exporting {
+		tracer.handleExportOutcome(<-tracer.exportDone)
 	}
-	if tracer.Config.Debug {
-		log.Printf("Final Traces: %s ", traceJSON)
+	tracer.triggerSend()
+	if tracer.exporting {
+		tracer.handleExportOutcome(<-tracer.exportDone)
 	}
-	return bytes.NewBuffer([]byte(traceJSON)), nil
 }
 
 func isChannelPinged(ch chan struct{}) bool {
@@ -109,18 +293,26 @@ func (tracer *epsagonTracer) Stopped() bool {
 	return isChannelPinged(tracer.stopped)
 }
 
-func fillConfigDefaults(config *Config) {
+func fillConfigDefaults(config *Config, runID string) {
 	if !config.Debug {
 		if os.Getenv("EPSAGON_DEBUG") == "TRUE" {
 			config.Debug = true
 		}
 	}
-	if len(config.Token) == 0 {
-		config.Token = os.Getenv("EPSAGON_TOKEN")
+	if config.LogLevel == 0 {
 		if config.Debug {
-			log.Println("EPSAGON DEBUG: setting token from environment variable")
+			config.LogLevel = LevelDebug
+		} else {
+			config.LogLevel = LevelInfo
 		}
 	}
+	if config.Logger == nil {
+		config.Logger = &stdLogger{Level: config.LogLevel}
+	}
+	if len(config.Token) == 0 {
+		config.Token = os.Getenv("EPSAGON_TOKEN")
+		config.Logger.Debugf("setting token from environment variable")
+	}
 	if len(config.CollectorURL) == 0 {
 		region := os.Getenv("AWS_REGION")
 		if len(region) != 0 {
@@ -128,10 +320,50 @@ func fillConfigDefaults(config *Config) {
 		} else {
 			config.CollectorURL = "http://us-east-1.tc.epsagon.com"
 		}
-		if config.Debug {
-			log.Printf("EPSAGON DEBUG: setting collector url to %s", config.CollectorURL)
+		config.Logger.Debugf("setting collector url to %s", config.CollectorURL)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Second}
+	}
+	if config.Exporter == nil {
+		config.Exporter = &HTTPExporter{
+			CollectorURL: config.CollectorURL,
+			Client:       config.HTTPClient,
+			Compression:  config.Compression,
+			MaxRetries:   config.MaxRetries,
 		}
 	}
+	if config.Sampler == nil && config.SampleRate > 0 && config.SampleRate < 1 {
+		config.Sampler = &rateSampler{rate: config.SampleRate, runID: runID}
+	}
+}
+
+const (
+	defaultBatchSize     = 50
+	defaultFlushInterval = 30 * time.Second
+	defaultMaxRetries    = 3
+)
+
+// generateRunID returns a random per-tracer identifier, used as the
+// fallback trace id for events this tracer produces directly (i.e. that
+// didn't arrive via the OTel bridge with their own trace_id). One tracer
+// instance corresponds to one invocation/runner lifecycle, so this keeps
+// all of that invocation's events sampled as a single unit.
+func generateRunID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
 }
 
 // CreateTracer will initiallize a global epsagon tracer
@@ -139,22 +371,29 @@ func CreateTracer(config *Config) {
 	mutex.Lock()
 	defer mutex.Unlock()
 	if globalTracer != nil && !globalTracer.Stopped() {
-		log.Println("The tracer is already created")
+		defaultLogger.Warnf("The tracer is already created")
 		return
 	}
-	fillConfigDefaults(config)
-	globalTracer = &epsagonTracer{
+	runID := generateRunID()
+	fillConfigDefaults(config, runID)
+	epTracer := &epsagonTracer{
 		Config:         config,
+		exporter:       config.Exporter,
 		eventsPipe:     make(chan *protocol.Event),
 		events:         make([]*protocol.Event, 0, 0),
 		exceptionsPipe: make(chan *protocol.Exception),
 		exceptions:     make([]*protocol.Exception, 0, 0),
 		closeCmd:       make(chan struct{}),
+		flushCmd:       make(chan chan struct{}),
+		exportDone:     make(chan exportOutcome),
 		stopped:        make(chan struct{}),
 		running:        make(chan struct{}),
+		level:          int32(config.LogLevel),
 	}
-	if config.Debug {
-		log.Println("EPSAGON DEBUG: Created a new tracer")
+	globalTracer = epTracer
+	epTracer.debugf("Created a new tracer")
+	if config.AdminListenAddr != "" {
+		epTracer.admin = startAdminServer(epTracer)
 	}
 	go globalTracer.Run()
 }
@@ -164,19 +403,28 @@ func (tracer *epsagonTracer) AddException(exception *protocol.Exception) {
 	tracer.exceptionsPipe <- exception
 }
 
-// AddEvent adds an event to the tracer
+// AddEvent adds an event to the tracer, first applying the configured
+// per-event-type filter, sampler and MetadataOnly redaction. Exceptions are
+// never sampled or filtered: errors matter even in a down-sampled trace.
 func (tracer *epsagonTracer) AddEvent(event *protocol.Event) {
-	tracer.eventsPipe <- event
-	if tracer.Config.Debug {
-		log.Println("EPSAGON DEBUG: Adding event: ", event)
+	if filtersEventType(tracer.Config, event) {
+		return
+	}
+	if tracer.Config.Sampler != nil && !tracer.Config.Sampler.ShouldSample(event) {
+		return
 	}
+	if tracer.Config.MetadataOnly {
+		applyMetadataOnly(event)
+	}
+	tracer.eventsPipe <- event
+	tracer.debugf("Adding event: %v", event)
 }
 
 // AddEvent adds an event to the tracer
 func AddEvent(event *protocol.Event) {
 	if globalTracer == nil || globalTracer.Stopped() {
 		// TODO
-		log.Println("The tracer is not initialized!")
+		defaultLogger.Warnf("The tracer is not initialized!")
 		return
 	}
 	globalTracer.AddEvent(event)
@@ -186,7 +434,7 @@ func AddEvent(event *protocol.Event) {
 func AddException(exception *protocol.Exception) {
 	if globalTracer == nil || globalTracer.Stopped() {
 		// TODO
-		log.Println("The tracer is not initialized!")
+		defaultLogger.Warnf("The tracer is not initialized!")
 		return
 	}
 	globalTracer.AddException(exception)
@@ -200,6 +448,9 @@ func (tracer *epsagonTracer) Stop() {
 	default:
 		tracer.closeCmd <- struct{}{}
 		<-tracer.stopped
+		if tracer.admin != nil {
+			tracer.admin.Stop()
+		}
 	}
 }
 
@@ -207,7 +458,7 @@ func (tracer *epsagonTracer) Stop() {
 func StopTracer() {
 	if globalTracer == nil || globalTracer.Stopped() {
 		// TODO
-		log.Println("The tracer is not initialized!")
+		defaultLogger.Warnf("The tracer is not initialized!")
 		return
 	}
 	globalTracer.Stop()
@@ -216,9 +467,7 @@ func StopTracer() {
 // Run starts the runner background routine that will
 // run until it
 func (tracer *epsagonTracer) Run() {
-	if tracer.Config.Debug {
-		log.Println("EPSAGON DEBUG: tracer started running")
-	}
+	tracer.debugf("tracer started running")
 	if tracer.Running() {
 		return
 	}
@@ -226,17 +475,34 @@ func (tracer *epsagonTracer) Run() {
 	defer func() { tracer.running = make(chan struct{}) }()
 	defer close(tracer.stopped)
 
+	ticker := time.NewTicker(tracer.Config.FlushInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case event := <-tracer.eventsPipe:
-			tracer.events = append(tracer.events, event)
+			tracer.appendEvent(event)
+			if tracer.bufferedCount() >= tracer.Config.BatchSize {
+				tracer.triggerSend()
+			}
 		case exception := <-tracer.exceptionsPipe:
-			tracer.exceptions = append(tracer.exceptions, exception)
-		case <-tracer.closeCmd:
-			if tracer.Config.Debug {
-				log.Println("EPSAGON DEBUG: tracer stops running, sending traces")
+			tracer.appendException(exception)
+			if tracer.bufferedCount() >= tracer.Config.BatchSize {
+				tracer.triggerSend()
 			}
-			tracer.sendTraces()
+		case <-ticker.C:
+			if tracer.bufferedCount() > 0 {
+				tracer.debugf("flush interval elapsed, sending traces")
+				tracer.triggerSend()
+			}
+		case outcome := <-tracer.exportDone:
+			tracer.handleExportOutcome(outcome)
+		case ack := <-tracer.flushCmd:
+			tracer.sendAndWait()
+			ack <- struct{}{}
+		case <-tracer.closeCmd:
+			tracer.debugf("tracer stops running, sending traces")
+			tracer.sendAndWait()
 			return
 		}
 	}
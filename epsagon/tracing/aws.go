@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const traceContextMiddlewareID = "EpsagonTraceContextPropagation"
+
+// InstrumentAWSConfig registers a Smithy serialize middleware on cfg that
+// injects the current W3C traceparent/tracestate headers onto every
+// outgoing AWS SDK request, so a trace started before an AWS call
+// continues into Epsagon's AWS Lambda/ECS instrumentation on the other
+// side.
+func InstrumentAWSConfig(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Serialize.Add(traceContextMiddleware{}, middleware.After)
+	})
+	return cfg
+}
+
+type traceContextMiddleware struct{}
+
+func (traceContextMiddleware) ID() string {
+	return traceContextMiddlewareID
+}
+
+func (traceContextMiddleware) HandleSerialize(
+	ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler,
+) (middleware.SerializeOutput, middleware.Metadata, error) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok {
+		propagator.Inject(ctx, awsHeaderCarrier{req})
+	}
+	return next.HandleSerialize(ctx, in)
+}
+
+// awsHeaderCarrier adapts a smithy *smithyhttp.Request's headers to
+// OTel's TextMapCarrier.
+type awsHeaderCarrier struct {
+	req *smithyhttp.Request
+}
+
+func (carrier awsHeaderCarrier) Get(key string) string {
+	return carrier.req.Header.Get(key)
+}
+
+func (carrier awsHeaderCarrier) Set(key, value string) {
+	carrier.req.Header.Set(key, value)
+}
+
+func (carrier awsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier.req.Header))
+	for key := range carrier.req.Header {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,18 @@
+package tracing
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+	otelbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewOpenTracingShim wraps an Epsagon-backed OpenTelemetry TracerProvider
+// (see NewTracerProvider) in an opentracing.Tracer, for services that are
+// already instrumented with OpenTracing and can't migrate to the OTel API
+// wholesale. Spans created through the returned tracer are converted to
+// OTel spans and, from there, to Epsagon events the same way as any other
+// OTel-instrumented call.
+func NewOpenTracingShim(provider *trace.TracerProvider) opentracing.Tracer {
+	shim, _ := otelbridge.NewTracerPair(provider.Tracer("epsagon/tracing"))
+	return shim
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+// propagator is the W3C Trace Context propagator (traceparent/tracestate)
+// used by all the helpers in this file.
+var propagator = propagation.TraceContext{}
+
+// InjectHTTP writes the W3C traceparent/tracestate headers carried by ctx
+// onto an outgoing *http.Request, so a downstream Epsagon- or
+// OTel-instrumented service can continue the same distributed trace.
+func InjectHTTP(ctx context.Context, req *http.Request) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// ExtractHTTP reads traceparent/tracestate headers off an incoming
+// *http.Request and returns a context carrying the remote span context.
+func ExtractHTTP(ctx context.Context, req *http.Request) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// grpcMetadataCarrier adapts gRPC metadata.MD to OTel's TextMapCarrier.
+type grpcMetadataCarrier metadata.MD
+
+func (carrier grpcMetadataCarrier) Get(key string) string {
+	values := metadata.MD(carrier).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (carrier grpcMetadataCarrier) Set(key, value string) {
+	metadata.MD(carrier).Set(key, value)
+}
+
+func (carrier grpcMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// InjectGRPCMetadata writes the W3C traceparent/tracestate carried by ctx
+// into outgoing gRPC metadata.
+func InjectGRPCMetadata(ctx context.Context, md metadata.MD) {
+	propagator.Inject(ctx, grpcMetadataCarrier(md))
+}
+
+// ExtractGRPCMetadata reads traceparent/tracestate off incoming gRPC
+// metadata and returns a context carrying the remote span context.
+func ExtractGRPCMetadata(ctx context.Context, md metadata.MD) context.Context {
+	return propagator.Extract(ctx, grpcMetadataCarrier(md))
+}
+
+func init() {
+	// Make the W3C propagator the process-wide default so
+	// instrumentation libraries that call otel.GetTextMapPropagator()
+	// pick it up automatically.
+	otel.SetTextMapPropagator(propagator)
+}
@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"github.com/epsagon/epsagon-go/epsagon"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewTracerProvider creates an Epsagon tracer from config and returns an
+// OpenTelemetry TracerProvider backed by it. Spans started from the
+// returned provider are converted to Epsagon events via SpanExporter and
+// flow through the usual batching/retry transport in epsagon.CreateTracer.
+//
+// Call provider.Shutdown to flush and stop both the OTel span processor and
+// the underlying Epsagon tracer. epsagon.StopTracer alone stops only the
+// Epsagon tracer; the OTel span processor keeps running.
+func NewTracerProvider(config *epsagon.Config) *sdktrace.TracerProvider {
+	epsagon.CreateTracer(config)
+	exporter := NewSpanExporter()
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+}
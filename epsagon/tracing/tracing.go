@@ -0,0 +1,115 @@
+// Package tracing bridges Epsagon's tracer with OpenTelemetry (and, through
+// the OpenTelemetry bridge, OpenTracing) so that a trace started in an
+// OTel-instrumented service carries through to Epsagon-instrumented
+// downstream calls, and vice versa.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/epsagon/epsagon-go/epsagon"
+	protocol "github.com/epsagon/epsagon-go/protocol"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanExporter implements the OpenTelemetry sdktrace.SpanExporter interface,
+// converting finished OTel spans into protocol.Events and feeding them into
+// an Epsagon tracer instead of (or in addition to) a dedicated collector
+// endpoint.
+type SpanExporter struct {
+	// AddEvent receives every converted event. It defaults to the global
+	// epsagon.AddEvent, but can be overridden (e.g. in tests) to capture
+	// events without a live tracer.
+	AddEvent func(*protocol.Event)
+}
+
+// NewSpanExporter returns a SpanExporter that feeds the global Epsagon
+// tracer created via epsagon.CreateTracer.
+func NewSpanExporter() *SpanExporter {
+	return &SpanExporter{AddEvent: epsagon.AddEvent}
+}
+
+// ExportSpans converts each ReadOnlySpan into a protocol.Event and hands it
+// to AddEvent. It implements sdktrace.SpanExporter.
+func (exporter *SpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	addEvent := exporter.AddEvent
+	if addEvent == nil {
+		addEvent = epsagon.AddEvent
+	}
+	for _, span := range spans {
+		addEvent(EventFromSpan(span))
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter by stopping the underlying
+// Epsagon tracer, so that the conventional OTel lifecycle (deferring
+// provider.Shutdown(ctx)) also flushes and stops Epsagon's tracer instead
+// of leaking it.
+func (exporter *SpanExporter) Shutdown(ctx context.Context) error {
+	epsagon.StopTracer()
+	return nil
+}
+
+// EventFromSpan converts a finished OpenTelemetry span into an Epsagon
+// protocol.Event, preserving the span's trace and span IDs so the event can
+// be correlated with the rest of the distributed trace.
+func EventFromSpan(span sdktrace.ReadOnlySpan) *protocol.Event {
+	spanCtx := span.SpanContext()
+	start := span.StartTime()
+	duration := span.EndTime().Sub(start)
+
+	metadata := map[string]string{
+		"trace_id": spanCtx.TraceID().String(),
+		"span_id":  spanCtx.SpanID().String(),
+	}
+	for _, attr := range span.Attributes() {
+		metadata[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	event := &protocol.Event{
+		Id:        spanCtx.SpanID().String(),
+		Origin:    "otel",
+		StartTime: float64(start.UnixNano()) / float64(time.Second),
+		Duration:  duration.Seconds(),
+		Resource: &protocol.Resource{
+			Name:      span.Name(),
+			Type:      otelResourceType(span.Attributes()),
+			Operation: span.SpanKind().String(),
+			Metadata:  metadata,
+		},
+	}
+	if span.Status().Code == codes.Error {
+		event.ErrorCode = protocol.ErrorCode_ERROR
+	}
+	return event
+}
+
+// otelResourceType maps a span's attributes onto the resource types that
+// epsagon.Config.MetadataOnly and Config.FilterEventTypes already know about
+// ("http", "database", "queue"), using the OTel semantic-convention key
+// prefixes, so bridged spans are redacted and filtered the same as natively
+// instrumented events instead of always reporting as the catch-all
+// "otel_span" and silently escaping both controls. Spans with no recognized
+// prefix keep "otel_span".
+func otelResourceType(attrs []attribute.KeyValue) string {
+	for _, attr := range attrs {
+		switch {
+		case strings.HasPrefix(string(attr.Key), "http."):
+			return "http"
+		case strings.HasPrefix(string(attr.Key), "db."):
+			return "database"
+		case strings.HasPrefix(string(attr.Key), "messaging."):
+			return "queue"
+		}
+	}
+	return "otel_span"
+}
+
+// Attribute is a re-export of attribute.KeyValue so callers converting an
+// Epsagon event into span attributes don't need a direct OTel import.
+type Attribute = attribute.KeyValue
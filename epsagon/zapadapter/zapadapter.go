@@ -0,0 +1,39 @@
+// Package zapadapter adapts a *zap.SugaredLogger to epsagon.Logger, so a
+// service that already logs with zap can route the tracer's diagnostics
+// through it instead of the standard log package.
+package zapadapter
+
+import (
+	"go.uber.org/zap"
+)
+
+// Adapter wraps a *zap.SugaredLogger as an epsagon.Logger.
+type Adapter struct {
+	Logger *zap.SugaredLogger
+}
+
+// New returns an Adapter around logger, ready to be set as
+// epsagon.Config.Logger (or via Config.WithLogger).
+func New(logger *zap.SugaredLogger) *Adapter {
+	return &Adapter{Logger: logger}
+}
+
+// Debugf implements epsagon.Logger.
+func (adapter *Adapter) Debugf(format string, args ...interface{}) {
+	adapter.Logger.Debugf(format, args...)
+}
+
+// Infof implements epsagon.Logger.
+func (adapter *Adapter) Infof(format string, args ...interface{}) {
+	adapter.Logger.Infof(format, args...)
+}
+
+// Warnf implements epsagon.Logger.
+func (adapter *Adapter) Warnf(format string, args ...interface{}) {
+	adapter.Logger.Warnf(format, args...)
+}
+
+// Errorf implements epsagon.Logger.
+func (adapter *Adapter) Errorf(format string, args ...interface{}) {
+	adapter.Logger.Errorf(format, args...)
+}